@@ -16,6 +16,14 @@ import (
 type Bidir struct {
 	Send *Pipe
 	Recv *Pipe
+
+	// For connections made through a Listener, Network.Dial/DialContext
+	// sets the client end's remoteAddr and the server end's localAddr to
+	// the Listener's name. The client's localAddr and the server's
+	// remoteAddr are left nil, as are both fields of a plain NewBidir
+	// pair; LocalAddr/RemoteAddr fall back to addr{} for a nil field.
+	localAddr  net.Addr
+	remoteAddr net.Addr
 }
 
 // NewBidir returns a pair of Bidir,
@@ -47,8 +55,16 @@ func (r *Bidir) Write(p []byte) (n int, err error) {
 	return r.Send.Write(p)
 }
 
+// Close closes both directions of the connection, so that a blocked
+// peer Read (on Recv) and a blocked peer Write (on Send) are both
+// released, matching net.Conn's full-duplex close semantics.
 func (c *Bidir) Close() error {
-	return c.Send.Close()
+	errSend := c.Send.Close()
+	errRecv := c.Recv.Close()
+	if errSend != nil {
+		return errSend
+	}
+	return errRecv
 }
 
 func (r *Bidir) SetErrorAndClose(err error) {
@@ -56,10 +72,33 @@ func (r *Bidir) SetErrorAndClose(err error) {
 	r.Send.SetErrorAndClose(err)
 }
 
+// SetReadBlock pauses or resumes the read side of the connection
+// without closing it. See Pipe.SetReadBlock.
+func (r *Bidir) SetReadBlock(block bool) error {
+	return r.Recv.SetReadBlock(block)
+}
+
+// SetWriteBlock pauses or resumes the write side of the connection
+// without closing it. See Pipe.SetWriteBlock.
+func (r *Bidir) SetWriteBlock(block bool) error {
+	return r.Send.SetWriteBlock(block)
+}
+
 // Bidir fullfills the net.Conn interface
 
-func (r *Bidir) LocalAddr() net.Addr  { return addr{} }
-func (r *Bidir) RemoteAddr() net.Addr { return addr{} }
+func (r *Bidir) LocalAddr() net.Addr {
+	if r.localAddr != nil {
+		return r.localAddr
+	}
+	return addr{}
+}
+
+func (r *Bidir) RemoteAddr() net.Addr {
+	if r.remoteAddr != nil {
+		return r.remoteAddr
+	}
+	return addr{}
+}
 
 // SetDeadline implements the net.Conn method
 func (r *Bidir) SetDeadline(t time.Time) error {
@@ -0,0 +1,89 @@
+// from https://github.com/bradfitz/http2/pull/8/files
+//
+// motivation: https://groups.google.com/forum/#!topic/golang-dev/k0bSal8eDyE
+//
+// Copyright 2014 The Go Authors.
+// See https://code.google.com/p/go/source/browse/CONTRIBUTORS
+// Licensed under the same terms as Go itself:
+// https://code.google.com/p/go/source/browse/LICENSE
+
+package lcon
+
+// buffer is a fixed-size ring buffer of bytes. Reads and writes on
+// it are not synchronized; callers (Pipe) are responsible for
+// holding the appropriate lock. Deadline handling lives in Pipe, not
+// here: buffer only knows about closed/err.
+type buffer struct {
+	buf    []byte
+	off    int // read position within buf
+	len    int // number of unread bytes currently stored
+	closed bool
+	err    error
+}
+
+// Len reports the number of unread bytes in the buffer.
+func (b *buffer) Len() int { return b.len }
+
+// freeBytes reports how many more bytes can be written before
+// the buffer is full.
+func (b *buffer) freeBytes() int { return len(b.buf) - b.len }
+
+// Read copies bytes out of the ring into p, returning how many bytes
+// were copied. If the buffer is empty, Read returns (0, b.err) once
+// closed, or (0, nil) otherwise (the caller is expected to have
+// already decided it's not worth waiting any longer, e.g. a deadline
+// fired).
+func (b *buffer) Read(p []byte) (n int, err error) {
+	if b.len == 0 {
+		if b.closed {
+			return 0, b.err
+		}
+		return 0, nil
+	}
+	n = len(p)
+	if n > b.len {
+		n = b.len
+	}
+	m := copy(p[:n], b.buf[b.off:])
+	if m < n {
+		copy(p[m:n], b.buf[:n-m])
+	}
+	b.off = (b.off + n) % len(b.buf)
+	b.len -= n
+	return n, nil
+}
+
+// Write copies all of p into the ring buffer. The caller must ensure
+// there is enough free space before calling Write, or that it's
+// willing to receive the (0, nil) "didn't fit" result.
+//
+// Write intentionally does not return b.err once closed: b.err is the
+// error Read should report at end-of-stream (e.g. io.EOF, to play
+// nicely with io.Copy), which is not the right error for a caller
+// that tried to write to an already-closed pipe.
+func (b *buffer) Write(p []byte) (n int, err error) {
+	if b.closed {
+		return 0, ErrLconPipeClosed
+	}
+	if len(p) > b.freeBytes() {
+		return 0, nil
+	}
+	writeOff := (b.off + b.len) % len(b.buf)
+	m := copy(b.buf[writeOff:], p)
+	if m < len(p) {
+		copy(b.buf, p[m:])
+	}
+	b.len += len(p)
+	return len(p), nil
+}
+
+// Close marks the buffer closed, recording err as the error to be
+// returned from subsequent Reads once the buffered data is drained.
+// Only the first call has any effect.
+func (b *buffer) Close(err error) {
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.err = err
+}
@@ -0,0 +1,64 @@
+package lcon
+
+import (
+	"sync"
+	"time"
+)
+
+// pipeDeadline tracks whether a Read or Write deadline has been
+// exceeded. Arming it schedules a single time.AfterFunc timer rather
+// than parking a dedicated goroutine for the lifetime of every
+// blocked Read/Write call, and firing (or disarming) it invokes a
+// caller-supplied wake callback so any goroutine waiting on the
+// pipe's condition variables is released immediately. This mirrors
+// the "aLongTimeAgo" sentinel trick used by net.Pipe and tailscale's
+// memnet.Pipe: setting a deadline in the past fires it synchronously.
+type pipeDeadline struct {
+	mu       sync.Mutex
+	timer    *time.Timer
+	exceeded bool
+	wake     func()
+}
+
+// init wires the callback invoked whenever this deadline is armed,
+// disarmed, or fires. It must be called once before use.
+func (d *pipeDeadline) init(wake func()) {
+	d.wake = wake
+}
+
+// set arms the deadline for t, disarms it for the zero Time, or fires
+// it immediately if t is already in the past.
+func (d *pipeDeadline) set(t time.Time) {
+	d.mu.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	switch {
+	case t.IsZero():
+		d.exceeded = false
+	case !t.After(time.Now()):
+		d.exceeded = true
+	default:
+		d.exceeded = false
+		d.timer = time.AfterFunc(time.Until(t), d.fire)
+	}
+	d.mu.Unlock()
+	d.wake()
+}
+
+// fire is the timer callback; it marks the deadline exceeded and
+// wakes anything blocked on it.
+func (d *pipeDeadline) fire() {
+	d.mu.Lock()
+	d.exceeded = true
+	d.mu.Unlock()
+	d.wake()
+}
+
+// isExceeded reports whether the deadline has passed.
+func (d *pipeDeadline) isExceeded() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.exceeded
+}
@@ -0,0 +1,22 @@
+package lcon
+
+import "os"
+
+// deadlineExceededError is returned by Read/Write once a deadline has
+// passed. It implements net.Error so callers that branch on
+// Timeout() (including golang.org/x/net/nettest and the stdlib HTTP
+// server) see a retryable timeout rather than a fatal error, and its
+// Is method makes errors.Is(err, os.ErrDeadlineExceeded) report true,
+// matching the real net package's deadline error.
+type deadlineExceededError struct{}
+
+func (deadlineExceededError) Error() string   { return "lcon: deadline exceeded" }
+func (deadlineExceededError) Timeout() bool   { return true }
+func (deadlineExceededError) Temporary() bool { return true }
+func (deadlineExceededError) Is(target error) bool {
+	return target == os.ErrDeadlineExceeded
+}
+
+// ErrDeadline is returned by Read and Write once their respective
+// deadline has passed.
+var ErrDeadline error = deadlineExceededError{}
@@ -0,0 +1,23 @@
+// Package lcontest provides test helpers for exercising lcon
+// connections against the standard library's connection conformance
+// tests (golang.org/x/net/nettest).
+package lcontest
+
+import "github.com/glycerine/lcon"
+
+// pipeBufSize is sized well above the 1024-byte chunks that
+// nettest's conformance suite writes at a time, so MakePipe's pair
+// never needs an in-flight Write larger than the buffer can hold.
+const pipeBufSize = 64 * 1024
+
+// MakePipe returns a connected pair of in-memory lcon.Bidir endpoints,
+// anything written to c1 is read by c2 and vice-versa, along with a
+// stop function that closes both ends. It is meant to be adapted into
+// a nettest.MakePipe for use with nettest.TestConn.
+func MakePipe() (c1, c2 *lcon.Bidir, stop func()) {
+	a, b := lcon.NewBidir(pipeBufSize)
+	return a, b, func() {
+		a.Close()
+		b.Close()
+	}
+}
@@ -0,0 +1,19 @@
+package lcontest
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/nettest"
+)
+
+// TestConnConformance runs the golang.org/x/net/nettest.TestConn
+// conformance suite against MakePipe, exercising BasicIO, PingPong,
+// RacyRead, RacyWrite, the various deadline tests, CloseTimeout, and
+// ConcurrentMethods.
+func TestConnConformance(t *testing.T) {
+	nettest.TestConn(t, func() (c1, c2 net.Conn, stop func(), err error) {
+		c1, c2, stop = MakePipe()
+		return c1, c2, stop, nil
+	})
+}
@@ -0,0 +1,152 @@
+package lcon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// listenerBufSize is the size of the Bidir buffers created for
+// connections accepted through a Listener.
+const listenerBufSize = 32 * 1024
+
+// lconAddr names an in-memory endpoint registered with a Network. It
+// implements net.Addr.
+type lconAddr string
+
+func (a lconAddr) Network() string { return "lcon" }
+func (a lconAddr) String() string  { return string(a) }
+
+// Network is a named registry of Listeners, letting several
+// independent in-memory networks coexist in one process (e.g. one
+// per test) without their listener names colliding, the way
+// tailscale's memnet.Network isolates its own listeners. The zero
+// Network is ready to use.
+type Network struct {
+	mu        sync.Mutex
+	listeners map[string]*Listener
+}
+
+// Listen registers and returns a new Listener under name, which
+// becomes its Addr(). It is an error to Listen twice on the same
+// name within a Network without an intervening Close.
+func (nw *Network) Listen(name string) (*Listener, error) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	if nw.listeners == nil {
+		nw.listeners = make(map[string]*Listener)
+	}
+	if _, exists := nw.listeners[name]; exists {
+		return nil, fmt.Errorf("lcon: listener %q already in use", name)
+	}
+	ln := &Listener{
+		nw:    nw,
+		addr:  lconAddr(name),
+		conns: make(chan *Bidir),
+		done:  make(chan struct{}),
+	}
+	nw.listeners[name] = ln
+	return ln, nil
+}
+
+// Dial connects to the Listener registered under name and returns the
+// client end of the pair; the server end is delivered to that
+// Listener's Accept.
+func (nw *Network) Dial(name string) (*Bidir, error) {
+	return nw.DialContext(context.Background(), name)
+}
+
+// DialContext is like Dial but also observes ctx's cancellation while
+// waiting for the Listener to Accept.
+func (nw *Network) DialContext(ctx context.Context, name string) (*Bidir, error) {
+	nw.mu.Lock()
+	ln, ok := nw.listeners[name]
+	nw.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("lcon: no listener registered for %q", name)
+	}
+
+	client, server := NewBidir(listenerBufSize)
+	client.remoteAddr = ln.addr
+	server.localAddr = ln.addr
+
+	select {
+	case ln.conns <- server:
+	case <-ln.done:
+		return nil, fmt.Errorf("lcon: listener %q closed", name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return client, nil
+}
+
+// Listener is an in-memory net.Listener backed by a Network. Each
+// connection delivered to Accept is one end of a Bidir pair whose
+// other end is returned by the Network's Dial/DialContext.
+type Listener struct {
+	nw   *Network
+	addr lconAddr
+
+	mu     sync.Mutex
+	closed bool
+	conns  chan *Bidir
+	done   chan struct{}
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, fmt.Errorf("lcon: listener %q closed", l.addr)
+	}
+}
+
+// Close stops the listener from accepting further connections and
+// unregisters it from its Network. Pending Dial calls blocked on
+// delivering to Accept receive an error rather than panicking, since
+// shutdown is signaled via done rather than closing conns (closing
+// conns would race a blocked DialContext's send on it).
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.done)
+
+	l.nw.mu.Lock()
+	if l.nw.listeners[string(l.addr)] == l {
+		delete(l.nw.listeners, string(l.addr))
+	}
+	l.nw.mu.Unlock()
+	return nil
+}
+
+// Addr returns the name this Listener was registered under.
+func (l *Listener) Addr() net.Addr { return l.addr }
+
+// DefaultNetwork is the Network used by the package-level Listen,
+// Dial, and DialContext functions.
+var DefaultNetwork = &Network{}
+
+// Listen registers and returns a new Listener under name on
+// DefaultNetwork. See Network.Listen.
+func Listen(name string) (*Listener, error) {
+	return DefaultNetwork.Listen(name)
+}
+
+// Dial connects to the Listener registered under name on
+// DefaultNetwork. See Network.Dial.
+func Dial(name string) (*Bidir, error) {
+	return DefaultNetwork.Dial(name)
+}
+
+// DialContext connects to the Listener registered under name on
+// DefaultNetwork. See Network.DialContext.
+func DialContext(ctx context.Context, name string) (*Bidir, error) {
+	return DefaultNetwork.DialContext(ctx, name)
+}
@@ -0,0 +1,144 @@
+package lcon
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestListenerDialRoundtrip(t *testing.T) {
+	nw := &Network{}
+
+	ln, err := nw.Listen("svc-a")
+	if err != nil {
+		t.Fatalf("Listen err = %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan error, 1)
+	var server *Bidir
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			server, _ = c.(*Bidir)
+		}
+		accepted <- err
+	}()
+
+	client, err := nw.Dial("svc-a")
+	if err != nil {
+		t.Fatalf("Dial err = %v", err)
+	}
+	if err := <-accepted; err != nil {
+		t.Fatalf("Accept err = %v", err)
+	}
+
+	if client.RemoteAddr().String() != "svc-a" {
+		t.Errorf("client.RemoteAddr() = %q, want %q", client.RemoteAddr(), "svc-a")
+	}
+	if server.LocalAddr().String() != "svc-a" {
+		t.Errorf("server.LocalAddr() = %q, want %q", server.LocalAddr(), "svc-a")
+	}
+
+	msg := "ping"
+	if _, err := client.Write([]byte(msg)); err != nil {
+		t.Fatalf("Write err = %v", err)
+	}
+	got := make([]byte, len(msg))
+	if _, err := io.ReadFull(server, got); err != nil {
+		t.Fatalf("Read err = %v", err)
+	}
+	if string(got) != msg {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestDialWithoutListenerErrors(t *testing.T) {
+	nw := &Network{}
+	if _, err := nw.Dial("nobody-home"); err == nil {
+		t.Fatal("Dial to an unregistered name should have failed")
+	}
+}
+
+func TestListenTwiceOnSameNameErrors(t *testing.T) {
+	nw := &Network{}
+	ln, err := nw.Listen("dup")
+	if err != nil {
+		t.Fatalf("Listen err = %v", err)
+	}
+	defer ln.Close()
+
+	if _, err := nw.Listen("dup"); err == nil {
+		t.Fatal("second Listen on the same name should have failed")
+	}
+}
+
+// TestCloseUnblocksPendingDialWithoutPanic reproduces a Dial parked
+// waiting for an Accept that never comes, then closes the Listener
+// out from under it. The Dial must return an error, not panic with a
+// send on a closed channel.
+func TestCloseUnblocksPendingDialWithoutPanic(t *testing.T) {
+	nw := &Network{}
+	ln, err := nw.Listen("svc-b")
+	if err != nil {
+		t.Fatalf("Listen err = %v", err)
+	}
+
+	dialErr := make(chan error, 1)
+	go func() {
+		_, err := nw.Dial("svc-b")
+		dialErr <- err
+	}()
+
+	// Give the Dial a chance to park on the unbuffered conns channel
+	// before Close runs.
+	time.Sleep(20 * time.Millisecond)
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close err = %v", err)
+	}
+
+	select {
+	case err := <-dialErr:
+		if err == nil {
+			t.Fatal("Dial on a closed Listener should have returned an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dial never returned after Close")
+	}
+}
+
+// TestListenerServesHTTP confirms a Listener can stand in for a real
+// net.Listener with net/http, as it would for an in-process test server.
+func TestListenerServesHTTP(t *testing.T) {
+	nw := &Network{}
+	ln, err := nw.Listen("http-svc")
+	if err != nil {
+		t.Fatalf("Listen err = %v", err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	conn, err := nw.Dial("http-svc")
+	if err != nil {
+		t.Fatalf("Dial err = %v", err)
+	}
+
+	if _, err := conn.Write([]byte("GET / HTTP/1.0\r\nHost: http-svc\r\n\r\n")); err != nil {
+		t.Fatalf("Write err = %v", err)
+	}
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read err = %v", err)
+	}
+	if !bytes.Contains(buf[:n], []byte("hello")) {
+		t.Errorf("response did not contain body, got: %q", buf[:n])
+	}
+}
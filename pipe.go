@@ -12,6 +12,7 @@ package lcon
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"sync"
 	"time"
@@ -20,15 +21,26 @@ import (
 // Pipe is buffered version of net.Pipe. Reads
 // will block until data is available.
 type Pipe struct {
-	b       buffer
-	rc      sync.Cond
-	wc      sync.Cond
-	rm      sync.Mutex
-	wm      sync.Mutex
-	Flushed chan bool
+	mu sync.Mutex
+
+	// notEmpty is signaled whenever bytes become readable; notFull is
+	// signaled whenever space to write frees up. Keeping these as two
+	// sync.Cond sharing one mutex (rather than a single condition
+	// variable) means a Write only has to wake blocked readers, and a
+	// Read only has to wake blocked writers, instead of every waiter
+	// re-checking the other side's predicate on every broadcast.
+	notEmpty sync.Cond
+	notFull  sync.Cond
+
+	b buffer
+
+	readDeadline  pipeDeadline
+	writeDeadline pipeDeadline
 
-	readDeadline  time.Time
-	writeDeadline time.Time
+	readBlocked  bool
+	writeBlocked bool
+
+	Flushed chan bool
 }
 
 // NewPipe must be given a buf of
@@ -40,100 +52,131 @@ func NewPipe(buf []byte) *Pipe {
 		b:       buffer{buf: buf},
 		Flushed: make(chan bool, 1),
 	}
-	p.rc = *sync.NewCond(&p.rm)
+	p.notEmpty = *sync.NewCond(&p.mu)
+	p.notFull = *sync.NewCond(&p.mu)
+	p.readDeadline.init(func() {
+		p.mu.Lock()
+		p.notEmpty.Broadcast()
+		p.mu.Unlock()
+	})
+	p.writeDeadline.init(func() {
+		p.mu.Lock()
+		p.notFull.Broadcast()
+		p.mu.Unlock()
+	})
 	return p
 }
 
-var ErrDeadline = fmt.Errorf("deadline exceeded")
-
 // Read waits until data is available and copies bytes
 // from the buffer into p.
 func (r *Pipe) Read(p []byte) (n int, err error) {
-	r.rc.L.Lock()
-	defer r.rc.L.Unlock()
-	if !r.readDeadline.IsZero() {
-		now := time.Now()
-		dur := r.readDeadline.Sub(now)
-		if dur <= 0 {
-			return 0, ErrDeadline
-		}
-		nextReadDone := make(chan struct{})
-		defer close(nextReadDone)
-		go func(dur time.Duration) {
-			select {
-			case <-time.After(dur):
-				r.rc.L.Lock()
-				r.b.late = true
-				r.rc.L.Unlock()
-				r.rc.Broadcast()
-			case <-nextReadDone:
-			}
-		}(dur)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for (r.b.Len() == 0 || r.readBlocked) && !r.b.closed && !r.readDeadline.isExceeded() {
+		r.notEmpty.Wait()
 	}
-	for r.b.Len() == 0 && !r.b.closed && !r.b.late {
-		r.rc.Wait()
+	if r.b.closed {
+		return r.b.Read(p)
 	}
-	defer func() {
-		// we already hold the lock
-		r.b.late = false
-		r.readDeadline = time.Time{}
-	}()
-	return r.b.Read(p)
+	if r.readDeadline.isExceeded() {
+		return 0, ErrDeadline
+	}
+	n, err = r.b.Read(p)
+	r.notFull.Broadcast()
+	return n, err
 }
 
-// Write copies bytes from p into the buffer and wakes a reader.
-// It is an error to write more data than the buffer can hold.
+// Write copies bytes from p into the buffer, looping as needed (and
+// waking readers along the way) until all of p has been written or
+// the deadline/close fires. p may be arbitrarily large, even larger
+// than the pipe's internal buffer, matching real TCP semantics where
+// a single Write succeeds as long as the peer eventually reads.
 func (r *Pipe) Write(p []byte) (n int, err error) {
-	r.rc.L.Lock()
-	defer r.rc.L.Unlock()
-	if !r.writeDeadline.IsZero() {
-		now := time.Now()
-		dur := r.writeDeadline.Sub(now)
-		if dur <= 0 {
-			return 0, ErrDeadline
-		}
-		nextWriteDone := make(chan struct{})
-		defer close(nextWriteDone)
-		go func(dur time.Duration) {
-			select {
-			case <-time.After(dur):
-				r.rc.L.Lock()
-				r.b.late = true
-				r.rc.L.Unlock()
-				r.rc.Broadcast()
-			case <-nextWriteDone:
-			}
-		}(dur)
-	}
-	defer r.rc.Broadcast()
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	defer r.flush()
 
-	for r.b.freeBytes() < len(p) && !r.b.closed && !r.b.late {
-		r.rc.Wait()
-	}
-	defer func() {
-		// we already hold the lock
-		r.b.late = false
-		r.writeDeadline = time.Time{}
-	}()
+	for n < len(p) {
+		for (r.b.freeBytes() == 0 || r.writeBlocked) && !r.b.closed && !r.writeDeadline.isExceeded() {
+			r.notFull.Wait()
+		}
+		if r.b.closed {
+			_, err = r.b.Write(nil)
+			return n, err
+		}
+		if r.writeDeadline.isExceeded() {
+			return n, ErrDeadline
+		}
 
-	return r.b.Write(p)
+		chunk := p[n:]
+		if free := r.b.freeBytes(); free < len(chunk) {
+			chunk = chunk[:free]
+		}
+		m, _ := r.b.Write(chunk)
+		n += m
+		r.notEmpty.Broadcast()
+	}
+	return n, nil
 }
 
+// ErrLconPipeClosed is available for callers that want to close a
+// Pipe with a distinguishable error via SetErrorAndClose. Close
+// itself uses io.EOF, so that a drained, closed Pipe plays cleanly
+// with idiomatic io.Copy loops.
 var ErrLconPipeClosed = fmt.Errorf("lcon pipe closed")
 
 func (c *Pipe) Close() error {
-	c.SetErrorAndClose(ErrLconPipeClosed)
+	c.SetErrorAndClose(io.EOF)
 	return nil
 }
 
 func (r *Pipe) SetErrorAndClose(err error) {
-	r.rc.L.Lock()
-	defer r.rc.L.Unlock()
-	defer r.rc.Broadcast()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.notEmpty.Broadcast()
+	defer r.notFull.Broadcast()
 	r.b.Close(err)
 }
 
+var ErrAlreadyBlocked = fmt.Errorf("already in requested block state")
+
+// SetReadBlock pauses (block == true) or resumes (block == false) the
+// read side of the pipe without closing it, for use in tests that
+// simulate a stalled or broken link. A blocked Read parks on the
+// pipe's condition variable until unblocked, closed, or its deadline
+// fires. It is an error to request a state the pipe is already in, or
+// to call this on a closed pipe.
+func (r *Pipe) SetReadBlock(block bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.b.closed {
+		return ErrLconPipeClosed
+	}
+	if r.readBlocked == block {
+		return ErrAlreadyBlocked
+	}
+	r.readBlocked = block
+	r.notEmpty.Broadcast()
+	return nil
+}
+
+// SetWriteBlock pauses (block == true) or resumes (block == false) the
+// write side of the pipe without closing it. See SetReadBlock.
+func (r *Pipe) SetWriteBlock(block bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.b.closed {
+		return ErrLconPipeClosed
+	}
+	if r.writeBlocked == block {
+		return ErrAlreadyBlocked
+	}
+	r.writeBlocked = block
+	r.notFull.Broadcast()
+	return nil
+}
+
 // Pipe technically fullfills the net.Conn interface
 
 func (r *Pipe) LocalAddr() net.Addr  { return addr{} }
@@ -162,16 +205,12 @@ func (r *Pipe) SetDeadline(t time.Time) error {
 
 // SetWriteDeadline implements the net.Conn method
 func (r *Pipe) SetWriteDeadline(t time.Time) error {
-	r.rc.L.Lock()
-	r.writeDeadline = t
-	r.rc.L.Unlock()
+	r.writeDeadline.set(t)
 	return nil
 }
 
 // SetReadDeadline implements the net.Conn method
 func (r *Pipe) SetReadDeadline(t time.Time) error {
-	r.rc.L.Lock()
-	r.readDeadline = t
-	r.rc.L.Unlock()
+	r.readDeadline.set(t)
 	return nil
 }
@@ -12,7 +12,10 @@ package lcon
 import (
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
+	"os"
 	"runtime"
 	"testing"
 	"time"
@@ -20,7 +23,6 @@ import (
 
 func TestPipeClose(t *testing.T) {
 	var p Pipe
-	p.rc.L = &p.rm
 	a := errors.New("a")
 	b := errors.New("b")
 	p.SetErrorAndClose(a)
@@ -190,7 +192,13 @@ func TestReadDeadlinesWork(t *testing.T) {
 	}
 	fmt.Printf("good, err = '%v' after %s.\n", err, elap)
 
-	// and should be able to read successfully after timeout:
+	// deadlines persist until explicitly reset (matching net.Conn), so
+	// clear it before expecting further I/O to succeed.
+	if err := nc.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatalf("must be able to clear ReadDeadline")
+	}
+
+	// and should be able to read successfully after clearing the deadline:
 	msg := []byte("jabber")
 	_, err = nc.Write(msg)
 	if err != nil {
@@ -247,9 +255,181 @@ func TestWriteDeadlinesWork(t *testing.T) {
 	}
 	fmt.Printf("good, err = '%v' after %s.\n", err, elap)
 
+	// deadlines persist until explicitly reset (matching net.Conn), so
+	// clear it before expecting further I/O to succeed.
+	if err := nc.SetWriteDeadline(time.Time{}); err != nil {
+		t.Fatalf("must be able to clear WriteDeadline")
+	}
+
+	// the timed-out write above loops internally and so leaves
+	// whatever fit (the first 10 bytes) sitting in the buffer; drain
+	// it before reusing the pipe.
+	if _, err := nc.Read(make([]byte, 10)); err != nil {
+		t.Fatalf("should have been able to drain the partial write: '%s'", err)
+	}
+
 	// should be able to write small ok...
 	_, err = nc.Write(writebuf[:5])
 	if err != nil {
 		t.Fatalf("small write of 5 to a capacity 10 buffer should work fine: '%s'", err)
 	}
 }
+
+func TestWriteLargerThanBufferSucceedsAsPeerDrains(t *testing.T) {
+
+	p := NewPipe(make([]byte, 10))
+
+	want := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(want)
+
+	writeErr := make(chan error, 1)
+	go func() {
+		_, err := p.Write(want)
+		writeErr <- err
+	}()
+
+	got := make([]byte, 0, len(want))
+	readbuf := make([]byte, 7)
+	for len(got) < len(want) {
+		n, err := p.Read(readbuf)
+		if err != nil {
+			t.Fatalf("unexpected Read error: %s", err)
+		}
+		got = append(got, readbuf[:n]...)
+	}
+
+	if err := <-writeErr; err != nil {
+		t.Fatalf("Write of a payload 100x the buffer size should succeed, got: '%s'", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("data corrupted across a write larger than the buffer")
+	}
+}
+
+func TestSetReadBlockPausesAndResumesReads(t *testing.T) {
+
+	p := NewPipe(make([]byte, 100))
+
+	if err := p.SetReadBlock(true); err != nil {
+		t.Fatalf("SetReadBlock(true) should succeed, got: '%s'", err)
+	}
+	if err := p.SetReadBlock(true); err != ErrAlreadyBlocked {
+		t.Fatalf("SetReadBlock(true) while already blocked should return ErrAlreadyBlocked, got: %v", err)
+	}
+
+	msg := []byte("hello")
+	if _, err := p.Write(msg); err != nil {
+		t.Fatalf("Write should not be affected by a read block, got: '%s'", err)
+	}
+
+	readdone := make(chan struct{})
+	readbuf := make([]byte, len(msg))
+	go func() {
+		p.Read(readbuf)
+		close(readdone)
+	}()
+	select {
+	case <-readdone:
+		t.Fatal("Read should have blocked while read-blocked")
+	case <-time.After(60 * time.Millisecond):
+		// good, read should still be parked.
+	}
+
+	if err := p.SetReadBlock(false); err != nil {
+		t.Fatalf("SetReadBlock(false) should succeed, got: '%s'", err)
+	}
+	<-readdone
+	if string(readbuf) != string(msg) {
+		t.Fatalf("read wrong data after unblocking: got '%s' want '%s'", readbuf, msg)
+	}
+}
+
+func TestSetWriteBlockPausesAndResumesWrites(t *testing.T) {
+
+	p := NewPipe(make([]byte, 100))
+
+	if err := p.SetWriteBlock(true); err != nil {
+		t.Fatalf("SetWriteBlock(true) should succeed, got: '%s'", err)
+	}
+
+	msg := []byte("hello")
+	writedone := make(chan struct{})
+	go func() {
+		p.Write(msg)
+		close(writedone)
+	}()
+	select {
+	case <-writedone:
+		t.Fatal("Write should have blocked while write-blocked")
+	case <-time.After(60 * time.Millisecond):
+		// good, write should still be parked.
+	}
+
+	if err := p.SetWriteBlock(false); err != nil {
+		t.Fatalf("SetWriteBlock(false) should succeed, got: '%s'", err)
+	}
+	<-writedone
+
+	readbuf := make([]byte, len(msg))
+	if _, err := p.Read(readbuf); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if string(readbuf) != string(msg) {
+		t.Fatalf("read wrong data after unblocking: got '%s' want '%s'", readbuf, msg)
+	}
+}
+
+func TestSetBlockOnClosedPipeErrors(t *testing.T) {
+
+	p := NewPipe(make([]byte, 100))
+	p.Close()
+
+	if err := p.SetReadBlock(true); err != ErrLconPipeClosed {
+		t.Fatalf("SetReadBlock on a closed pipe should return ErrLconPipeClosed, got: %v", err)
+	}
+	if err := p.SetWriteBlock(true); err != ErrLconPipeClosed {
+		t.Fatalf("SetWriteBlock on a closed pipe should return ErrLconPipeClosed, got: %v", err)
+	}
+}
+
+func TestErrDeadlineSatisfiesNetError(t *testing.T) {
+	ne, ok := ErrDeadline.(net.Error)
+	if !ok {
+		t.Fatalf("ErrDeadline does not implement net.Error")
+	}
+	if !ne.Timeout() {
+		t.Errorf("ErrDeadline.Timeout() = false, want true")
+	}
+	if !ne.Temporary() {
+		t.Errorf("ErrDeadline.Temporary() = false, want true")
+	}
+	if !errors.Is(ErrDeadline, os.ErrDeadlineExceeded) {
+		t.Errorf("errors.Is(ErrDeadline, os.ErrDeadlineExceeded) = false, want true")
+	}
+}
+
+func TestReadAfterCloseReturnsEOF(t *testing.T) {
+	p := NewPipe(make([]byte, 10))
+	if _, err := p.Write([]byte("hi")); err != nil {
+		t.Fatalf("Write err = %v", err)
+	}
+	p.Close()
+
+	// buffered data should still be drained before EOF shows up.
+	buf := make([]byte, 2)
+	if n, err := p.Read(buf); n != 2 || err != nil {
+		t.Fatalf("Read of buffered data before EOF: n=%d err=%v", n, err)
+	}
+	if _, err := p.Read(buf); err != io.EOF {
+		t.Fatalf("Read after drained+closed = %v, want io.EOF", err)
+	}
+}
+
+func TestWriteAfterCloseReturnsDistinguishableError(t *testing.T) {
+	p := NewPipe(make([]byte, 10))
+	p.Close()
+
+	if _, err := p.Write([]byte("hi")); err != ErrLconPipeClosed {
+		t.Fatalf("Write after close = %v, want ErrLconPipeClosed", err)
+	}
+}